@@ -1,86 +1,133 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"slices"
 	"syscall"
+
+	"github.com/davmac314/dinit/contrib/dinit-edit/internal/config"
+	"github.com/davmac314/dinit/contrib/dinit-edit/internal/xdg"
 )
 
 func getServiceDirs() (serviceDirs []string) {
 	if os.Getuid() == 0 {
 		serviceDirs = []string{"/etc/dinit.d", "/run/dinit.d", "/usr/local/lib/dinit.d", "/lib/dinit.d"}
 	} else {
-		if xdg_home, ok := os.LookupEnv("XDG_CONFIG_HOME"); ok {
-			serviceDirs = append(serviceDirs, path.Join(xdg_home, "dinit.d"))
-		}
-		if home, ok := os.LookupEnv("HOME"); ok {
-			serviceDirs = append(serviceDirs, path.Join(home, ".config/dinit.d"))
-		}
-		serviceDirs = slices.Concat(serviceDirs, []string{"/etc/dinit.d/user", "/usr/lib/dinit.d/user", "/usr/local/lib/dinit.d/user"})
+		serviceDirs = xdg.SearchDirs("dinit.d")
+		serviceDirs = append(serviceDirs, "/etc/dinit.d/user", "/usr/lib/dinit.d/user", "/usr/local/lib/dinit.d/user")
 	}
 	return
 }
 
-type Service struct {
-	Name string
-	Path string
-}
+var (
+	statusFlag      = flag.Bool("status", false, "annotate listed services with their live state from dinit")
+	runningOnlyFlag = flag.Bool("running-only", false, "list only services dinit reports as STARTING or STARTED (implies -status)")
+	failedOnlyFlag  = flag.Bool("failed-only", false, "list only services dinit reports as FAILED (implies -status)")
+	jsonFlag        = flag.Bool("json", false, "print the service list as JSON instead of a table")
+	overrideFlag    = flag.Bool("override", false, "edit (creating if necessary) a drop-in override file for the named service, rather than its base file")
+	scanTimeoutFlag = flag.Duration("scan-timeout", 0, "abort directory scanning after this long and work with a partial list (0 = no timeout); useful when a search directory is on a hung network mount")
+)
 
 func main() {
-	dirs := getServiceDirs()
-	services := []Service{}
-	for _, dir := range dirs {
-		entries, err := os.ReadDir(dir)
-		if err != nil {
-			// missing dir is ok
-			if !os.IsNotExist(err) {
-				fmt.Println(err)
-			}
-		} else {
-			for _, entry := range entries {
-				if entry.Type().IsRegular() {
-					name := entry.Name()
-					path := path.Join(dir, name)
-					services = append(services, Service{
-						Name: name,
-						Path: path,
-					})
-				}
-			}
-		}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		runImport(os.Args[2:])
+		return
+	}
+	flag.Parse()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dinit-edit:", err)
+		os.Exit(1)
 	}
 
-	if len(os.Args) < 2 {
+	dirs := append(getServiceDirs(), cfg.ServiceDirs...)
+	services := scanWithTimeout(dirs, *scanTimeoutFlag)
+
+	name := flag.Arg(0)
+	if name != "" {
+		name = cfg.Resolve(name)
+	}
+	switch {
+	case name == "":
 		listAll(services)
-	} else {
-		edit(services, os.Args[1])
+	case *overrideFlag:
+		editOverride(services, name, cfg)
+	default:
+		edit(services, name, cfg)
 	}
 }
 
-func listAll(services []Service) {
-	longest := 0
-	for _, srv := range services {
-		longest = max(longest, len(srv.Name))
-	}
-	for _, srv := range services {
-		fmt.Fprintf(os.Stdout, "%-*s%s\n", longest+4, srv.Name, srv.Path)
+// loadConfig loads dinit-edit's optional TOML config, using the XDG config
+// home for the user file's location. A user file can't be resolved (e.g.
+// HOME isn't set and XDG_CONFIG_HOME isn't either) is not fatal - the
+// system-wide file is still honored.
+func loadConfig() (config.Config, error) {
+	userConfigPath := ""
+	if home, err := xdg.ConfigHome(); err == nil {
+		userConfigPath = config.UserConfigPath(home)
 	}
+	return config.Load(userConfigPath)
 }
 
-func edit(services []Service, name string) {
+func edit(services []Service, name string, cfg config.Config) {
 	i := slices.IndexFunc(services, func(srv Service) bool { return srv.Name == name })
 	if i < 0 {
 		fmt.Println("service not found:", name)
 		os.Exit(1)
-	} else {
-		if editor_sh, ok := os.LookupEnv("EDITOR"); ok {
-			err := syscall.Exec("/bin/sh", []string{"sh", "-c", editor_sh + " " + services[i].Path}, os.Environ())
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-		}
+	}
+	openInEditor(services[i].BasePath, cfg)
+}
+
+// editOverride opens (creating if necessary) the highest-priority writable
+// override file for name: /etc/dinit.d/<name>.d/local.conf for root, or
+// $XDG_CONFIG_HOME/dinit.d/<name>.d/local.conf otherwise. This edits a
+// drop-in rather than the base service file, which may live in a read-only
+// system location such as /usr/lib/dinit.d.
+func editOverride(services []Service, name string, cfg config.Config) {
+	if !slices.ContainsFunc(services, func(srv Service) bool { return srv.Name == name }) {
+		fmt.Println("service not found:", name)
+		os.Exit(1)
+	}
+
+	overridePath, err := overrideTargetPath(name)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(path.Dir(overridePath), 0755); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	openInEditor(overridePath, cfg)
+}
+
+// overrideTargetPath returns the override file editOverride should create or
+// open for the named service, following the same root/non-root split as
+// getServiceDirs.
+func overrideTargetPath(name string) (string, error) {
+	if os.Getuid() == 0 {
+		return path.Join("/etc/dinit.d", name+".d", "local.conf"), nil
+	}
+	home, err := xdg.ConfigHome()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(home, "dinit.d", name+".d", "local.conf"), nil
+}
+
+func openInEditor(filePath string, cfg config.Config) {
+	editorSh := cfg.EditorFor(filepath.Ext(filePath), os.Getenv("EDITOR"))
+	if editorSh == "" {
+		return
+	}
+	err := syscall.Exec("/bin/sh", []string{"sh", "-c", editorSh + " " + filePath}, os.Environ())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 }
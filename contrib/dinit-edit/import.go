@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/davmac314/dinit/contrib/dinit-edit/internal/sysv"
+)
+
+// initdCandidates are the directories checked, in order, for SysV init
+// scripts. Only the first one found to exist is used.
+var initdCandidates = []string{"/etc/init.d", "/etc/rc.d/init.d"}
+
+// runlevelDirCandidates returns the candidate rcN.d directory paths for a
+// given runlevel, checked in order.
+func runlevelDirCandidates(level int) []string {
+	return []string{
+		fmt.Sprintf("/etc/rc%d.d", level),
+		fmt.Sprintf("/etc/rc.d/rc%d.d", level),
+	}
+}
+
+// runImport implements the "import" subcommand: it scans the system's SysV
+// init scripts and runlevel symlinks and writes equivalent dinit service
+// files into a target directory.
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print what would be written instead of writing files")
+	mergeOnly := fs.Bool("merge", false, "skip scripts whose service name already exists in a search directory")
+	fs.Parse(args)
+
+	targetDir := fs.Arg(0)
+	if targetDir == "" {
+		fmt.Fprintln(os.Stderr, "usage: dinit-edit import [--dry-run] [--merge] <target-dir>")
+		os.Exit(2)
+	}
+
+	initdDir, err := findExisting(initdCandidates)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dinit-edit import:", err)
+		os.Exit(1)
+	}
+
+	scripts, err := sysv.Discover(initdDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dinit-edit import:", err)
+		os.Exit(1)
+	}
+	sysv.FillRunlevelsFromSymlinks(scripts, runlevelDirs())
+
+	if *mergeOnly {
+		scripts = skipExisting(scripts, scanServices(getServiceDirs()))
+	}
+
+	for name, content := range generateServiceFiles(scripts) {
+		dest := path.Join(targetDir, name)
+		if *dryRun {
+			fmt.Printf("--- %s ---\n%s", dest, content)
+			continue
+		}
+		if err := os.MkdirAll(targetDir, 0755); err != nil {
+			fmt.Fprintln(os.Stderr, "dinit-edit import:", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(dest, []byte(content), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, "dinit-edit import:", err)
+			os.Exit(1)
+		}
+		fmt.Println("wrote", dest)
+	}
+}
+
+// findExisting returns the first candidate directory that exists.
+func findExisting(candidates []string) (string, error) {
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("none of %v exist", candidates)
+}
+
+// runlevelDirs resolves runlevelDirCandidates for runlevels 0-6 into the
+// map FillRunlevelsFromSymlinks expects, dropping levels with no existing
+// directory.
+func runlevelDirs() map[int]string {
+	dirs := map[int]string{}
+	for level := 0; level <= 6; level++ {
+		if dir, err := findExisting(runlevelDirCandidates(level)); err == nil {
+			dirs[level] = dir
+		}
+	}
+	return dirs
+}
+
+// skipExisting drops any script whose name is already present in existing,
+// for "--merge" imports that only want to add services dinit doesn't
+// already have.
+func skipExisting(scripts []sysv.Script, existing []Service) []sysv.Script {
+	present := make(map[string]bool, len(existing))
+	for _, srv := range existing {
+		present[srv.Name] = true
+	}
+	out := scripts[:0]
+	for _, s := range scripts {
+		if !present[s.Name] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// generateServiceFiles builds the dinit service file content for every
+// script, plus one "runlevel-N" meta-service per runlevel referenced,
+// chained to the runlevel below it so that starting runlevel-5, say, also
+// brings up everything runlevels 2 through 4 needed.
+func generateServiceFiles(scripts []sysv.Script) map[string]string {
+	provider := providerIndex(scripts)
+	files := make(map[string]string, len(scripts))
+	runlevelScripts := map[int][]string{}
+
+	for _, s := range scripts {
+		files[s.Name] = serviceFile(s, provider)
+		for _, level := range s.DefaultStart {
+			runlevelScripts[level] = append(runlevelScripts[level], s.Name)
+		}
+	}
+
+	levels := make([]int, 0, len(runlevelScripts))
+	for level := range runlevelScripts {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+
+	prevLevel, havePrev := 0, false
+	for _, level := range levels {
+		files[fmt.Sprintf("runlevel-%d", level)] = runlevelFile(level, prevLevel, havePrev, runlevelScripts[level])
+		prevLevel, havePrev = level, true
+	}
+	return files
+}
+
+// providerIndex maps each LSB Provides facility name to the script that
+// provides it, so Required-Start entries can be resolved to service names.
+func providerIndex(scripts []sysv.Script) map[string]string {
+	index := make(map[string]string)
+	for _, s := range scripts {
+		for _, provides := range s.Provides {
+			index[provides] = s.Name
+		}
+	}
+	return index
+}
+
+func serviceFile(s sysv.Script, provider map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "type = bgprocess\n")
+	fmt.Fprintf(&b, "command = %s start\n", s.Path)
+	fmt.Fprintf(&b, "stop-command = %s stop\n", s.Path)
+	for _, required := range s.RequiredStart {
+		// LSB virtual facilities ($network, $remote_fs, ...) have no
+		// single dinit service equivalent, so they're left for the user
+		// to wire up by hand.
+		if strings.HasPrefix(required, "$") {
+			continue
+		}
+		if dep, ok := provider[required]; ok && dep != s.Name {
+			fmt.Fprintf(&b, "depends-on = %s\n", dep)
+		}
+	}
+	return b.String()
+}
+
+func runlevelFile(level, prevLevel int, havePrev bool, scripts []string) string {
+	var b strings.Builder
+	b.WriteString("type = internal\n")
+	if havePrev {
+		fmt.Fprintf(&b, "waits-for = runlevel-%d\n", prevLevel)
+	}
+	for _, name := range scripts {
+		fmt.Fprintf(&b, "waits-for = %s\n", name)
+	}
+	return b.String()
+}
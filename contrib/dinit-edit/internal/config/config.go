@@ -0,0 +1,132 @@
+// Package config loads dinit-edit's optional TOML configuration: extra
+// service search directories, a preferred editor (overridable per file
+// extension), and service name aliases.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// systemConfigPath is the system-wide config file, read before the user's so
+// user settings win on conflicts (merged in Load).
+const systemConfigPath = "/etc/dinit-edit.toml"
+
+// Config is the decoded contents of a dinit-edit.toml file. Every field is
+// optional; the zero Config adds nothing on top of the built-in defaults.
+type Config struct {
+	// ServiceDirs are extra service search directories, appended after the
+	// built-in ones (lowest priority).
+	ServiceDirs []string `toml:"service_dirs"`
+	// Editor overrides $EDITOR. EditorByExt overrides Editor for service
+	// files with a matching extension (matched including the leading dot,
+	// e.g. ".conf").
+	Editor      string            `toml:"editor"`
+	EditorByExt map[string]string `toml:"editor_by_extension"`
+	// Aliases maps a short name to the real service name it should resolve
+	// to, e.g. "www" -> "nginx".
+	Aliases map[string]string `toml:"aliases"`
+}
+
+// Load reads and merges the system-wide config file (/etc/dinit-edit.toml)
+// and the user config file ($XDG_CONFIG_HOME/dinit-edit/config.toml),
+// with user settings taking precedence. Either file may be absent; a
+// missing file is not an error. Unknown keys in either file are a decode
+// error rather than being silently ignored, so typos are caught.
+func Load(userConfigPath string) (Config, error) {
+	var merged Config
+
+	sysCfg, err := loadFile(systemConfigPath)
+	if err != nil {
+		return Config{}, err
+	}
+	merged = merge(merged, sysCfg)
+
+	userCfg, err := loadFile(userConfigPath)
+	if err != nil {
+		return Config{}, err
+	}
+	merged = merge(merged, userCfg)
+
+	return merged, nil
+}
+
+// UserConfigPath returns the default location of the user config file under
+// configHome, dinit-edit's XDG config home (see the xdg package).
+func UserConfigPath(configHome string) string {
+	return filepath.Join(configHome, "dinit-edit", "config.toml")
+}
+
+// loadFile decodes path with a strict decoder, returning a zero Config if
+// the file doesn't exist.
+func loadFile(path string) (Config, error) {
+	var cfg Config
+	meta, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Config{}, nil
+		}
+		return Config{}, fmt.Errorf("config: %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return Config{}, fmt.Errorf("config: %s: unknown key %q", path, undecoded[0].String())
+	}
+	return cfg, nil
+}
+
+// merge overlays override on top of base: ServiceDirs is additive (base's
+// entries are kept, override's are appended after them), Editor is replaced
+// wholesale when override sets one, and EditorByExt/Aliases are merged key
+// by key, with override's value winning on a given key.
+func merge(base, override Config) Config {
+	out := base
+	if len(override.ServiceDirs) > 0 {
+		out.ServiceDirs = append(append([]string{}, base.ServiceDirs...), override.ServiceDirs...)
+	}
+	if override.Editor != "" {
+		out.Editor = override.Editor
+	}
+	if len(override.EditorByExt) > 0 {
+		out.EditorByExt = mergeStringMap(base.EditorByExt, override.EditorByExt)
+	}
+	if len(override.Aliases) > 0 {
+		out.Aliases = mergeStringMap(base.Aliases, override.Aliases)
+	}
+	return out
+}
+
+func mergeStringMap(base, override map[string]string) map[string]string {
+	out := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range override {
+		out[k] = v
+	}
+	return out
+}
+
+// EditorFor returns the editor command to use for a service file with the
+// given extension (including the leading dot, e.g. ".conf"), falling back
+// to Editor and then to fallback (typically $EDITOR) if neither is set.
+func (c Config) EditorFor(ext, fallback string) string {
+	if editor, ok := c.EditorByExt[ext]; ok && editor != "" {
+		return editor
+	}
+	if c.Editor != "" {
+		return c.Editor
+	}
+	return fallback
+}
+
+// Resolve returns the real service name for name, following one level of
+// alias lookup if name matches an alias, otherwise returning name unchanged.
+func (c Config) Resolve(name string) string {
+	if real, ok := c.Aliases[name]; ok {
+		return real
+	}
+	return name
+}
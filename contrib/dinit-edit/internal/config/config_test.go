@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoadMissingUserConfig(t *testing.T) {
+	cfg, err := loadFile(filepath.Join(t.TempDir(), "missing.toml"))
+	if err != nil {
+		t.Fatalf("loadFile() error = %v", err)
+	}
+	if len(cfg.ServiceDirs) != 0 || cfg.Editor != "" || len(cfg.EditorByExt) != 0 || len(cfg.Aliases) != 0 {
+		t.Errorf("loadFile() = %+v, want zero value", cfg)
+	}
+}
+
+func TestLoadFileRejectsUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	p := writeFile(t, dir, "config.toml", "edtior = \"vim\"\n")
+	if _, err := loadFile(p); err == nil {
+		t.Fatal("loadFile() error = nil, want error for unknown key")
+	}
+}
+
+func TestEditorForPrefersExtensionOverride(t *testing.T) {
+	cfg := Config{
+		Editor:      "vim",
+		EditorByExt: map[string]string{".conf": "nano"},
+	}
+	if got := cfg.EditorFor(".conf", "ed"); got != "nano" {
+		t.Errorf("EditorFor(.conf) = %q, want %q", got, "nano")
+	}
+	if got := cfg.EditorFor(".env", "ed"); got != "vim" {
+		t.Errorf("EditorFor(.env) = %q, want %q", got, "vim")
+	}
+}
+
+func TestEditorForFallsBackToFallback(t *testing.T) {
+	var cfg Config
+	if got := cfg.EditorFor(".conf", "ed"); got != "ed" {
+		t.Errorf("EditorFor() = %q, want %q", got, "ed")
+	}
+}
+
+func TestResolveAlias(t *testing.T) {
+	cfg := Config{Aliases: map[string]string{"www": "nginx"}}
+	if got := cfg.Resolve("www"); got != "nginx" {
+		t.Errorf("Resolve(www) = %q, want %q", got, "nginx")
+	}
+	if got := cfg.Resolve("nginx"); got != "nginx" {
+		t.Errorf("Resolve(nginx) = %q, want %q", got, "nginx")
+	}
+}
+
+func TestMergeUserOverridesSystem(t *testing.T) {
+	sys := Config{Editor: "vim", ServiceDirs: []string{"/etc/extra.d"}}
+	user := Config{Editor: "nano", ServiceDirs: []string{"/home/me/.config/extra.d"}}
+	got := merge(sys, user)
+	if got.Editor != "nano" {
+		t.Errorf("merge().Editor = %q, want %q", got.Editor, "nano")
+	}
+	want := []string{"/etc/extra.d", "/home/me/.config/extra.d"}
+	if len(got.ServiceDirs) != len(want) || got.ServiceDirs[0] != want[0] || got.ServiceDirs[1] != want[1] {
+		t.Errorf("merge().ServiceDirs = %v, want %v", got.ServiceDirs, want)
+	}
+}
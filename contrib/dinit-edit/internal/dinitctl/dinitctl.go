@@ -0,0 +1,279 @@
+// Package dinitctl implements just enough of dinit's control-socket binary
+// protocol to ask a running dinit instance whether it knows about a service
+// and, if so, what state that service is currently in. It deliberately
+// doesn't attempt to cover the full control protocol (starting/stopping
+// services, signal masks, etc) - dinit-edit only needs read-only status.
+package dinitctl
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Control protocol opcodes, as sent by the client. dinit-edit only ever
+// needs to resolve a name to a handle and ask that handle's status, so
+// LOADSERVICE doubles as the name lookup - there's no separate use for
+// QUERYSERVICENAME here.
+const (
+	cpQueryVersion  byte = 0
+	cpLoadService   byte = 2
+	cpServiceStatus byte = 16
+)
+
+// Control protocol reply codes, as sent by dinit.
+const (
+	rpCpVersion     byte = 0
+	rpServiceRecord byte = 16
+	rpServiceStatus byte = 17
+	rpNoService     byte = 18
+	rpBadReq        byte = 45
+)
+
+// dialTimeout bounds how long Dial will wait for the connect handshake
+// (version query) before giving up; dinit replies to it immediately, so a
+// hang here almost always means the socket is stale.
+const dialTimeout = 2 * time.Second
+
+// State is the run state of a service as reported by dinit.
+type State int
+
+const (
+	StateUnknown State = iota
+	StateStopped
+	StateStarting
+	StateStarted
+	StateStopping
+	StateFailed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateStopped:
+		return "STOPPED"
+	case StateStarting:
+		return "STARTING"
+	case StateStarted:
+		return "STARTED"
+	case StateStopping:
+		return "STOPPING"
+	case StateFailed:
+		return "FAILED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Status is what dinit knows about a single service.
+type Status struct {
+	Known bool
+	State State
+}
+
+// ErrNotRunning indicates no dinit control socket could be found - dinit
+// may not be running, or may have been started with a non-default socket
+// path that isn't advertised via any of the locations Discover checks.
+var ErrNotRunning = errors.New("dinitctl: no dinit control socket found")
+
+// Discover returns the path to dinit's control socket, checking
+// $DINIT_SOCKET_PATH first, then /run/dinitctl, then
+// $XDG_RUNTIME_DIR/dinitctl. A non-empty $DINIT_SOCKET_PATH is returned
+// as-is, without checking that it exists; otherwise it returns "" if neither
+// of the other two exists.
+func Discover() string {
+	if p, ok := os.LookupEnv("DINIT_SOCKET_PATH"); ok && p != "" {
+		return p
+	}
+	if _, err := os.Stat("/run/dinitctl"); err == nil {
+		return "/run/dinitctl"
+	}
+	if runtimeDir, ok := os.LookupEnv("XDG_RUNTIME_DIR"); ok && runtimeDir != "" {
+		p := filepath.Join(runtimeDir, "dinitctl")
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// Client is a connection to a dinit control socket.
+type Client struct {
+	conn net.Conn
+}
+
+// Dial connects to the dinit control socket at path and performs the
+// initial version query dinit expects of every client.
+func Dial(path string) (*Client, error) {
+	if path == "" {
+		return nil, ErrNotRunning
+	}
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("dinitctl: connect %s: %w", path, err)
+	}
+	c := &Client{conn: conn}
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	if err := c.queryVersion(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) queryVersion() error {
+	if err := c.write([]byte{cpQueryVersion}); err != nil {
+		return err
+	}
+	reply, err := c.readReply()
+	if err != nil {
+		return err
+	}
+	if reply != rpCpVersion {
+		return fmt.Errorf("dinitctl: unexpected reply %#x to version query", reply)
+	}
+	// Reply is followed by a 4-byte protocol version; we don't need it, but
+	// still have to drain it off the wire so later replies parse correctly.
+	return c.discard(4)
+}
+
+// ServiceStatus asks dinit whether it knows about the named service and,
+// if so, its current state. A service dinit has never loaded (because it
+// hasn't been referenced by any started service, or its file doesn't exist)
+// is reported as Status{Known: false}, not an error.
+func (c *Client) ServiceStatus(name string) (Status, error) {
+	handle, found, err := c.loadService(name)
+	if err != nil {
+		return Status{}, err
+	}
+	if !found {
+		return Status{Known: false}, nil
+	}
+	state, err := c.serviceState(handle)
+	if err != nil {
+		return Status{}, err
+	}
+	return Status{Known: true, State: state}, nil
+}
+
+func (c *Client) loadService(name string) (handle uint32, found bool, err error) {
+	nameBytes := []byte(name)
+	req := make([]byte, 0, 4+len(nameBytes))
+	req = append(req, cpLoadService)
+	req = appendUint16(req, uint16(len(nameBytes)))
+	req = append(req, nameBytes...)
+	if err := c.write(req); err != nil {
+		return 0, false, err
+	}
+	reply, err := c.readReply()
+	if err != nil {
+		return 0, false, err
+	}
+	switch reply {
+	case rpServiceRecord:
+		body, err := c.read(5)
+		if err != nil {
+			return 0, false, err
+		}
+		// body[0] is a reserved/padding byte in this reply that the
+		// protocol doesn't currently define a use for; the handle is
+		// body[1:5].
+		return binary.LittleEndian.Uint32(body[1:5]), true, nil
+	case rpNoService:
+		return 0, false, nil
+	default:
+		return 0, false, fmt.Errorf("dinitctl: unexpected reply %#x to LOADSERVICE", reply)
+	}
+}
+
+func (c *Client) serviceState(handle uint32) (State, error) {
+	req := make([]byte, 0, 5)
+	req = append(req, cpServiceStatus)
+	req = appendUint32(req, handle)
+	if err := c.write(req); err != nil {
+		return StateUnknown, err
+	}
+	reply, err := c.readReply()
+	if err != nil {
+		return StateUnknown, err
+	}
+	if reply != rpServiceStatus {
+		return StateUnknown, fmt.Errorf("dinitctl: unexpected reply %#x to SERVICESTATUS", reply)
+	}
+	body, err := c.read(1)
+	if err != nil {
+		return StateUnknown, err
+	}
+	return decodeState(body[0]), nil
+}
+
+func decodeState(b byte) State {
+	switch b {
+	case 0:
+		return StateStopped
+	case 1:
+		return StateStarting
+	case 2:
+		return StateStarted
+	case 3:
+		return StateStopping
+	case 4:
+		return StateFailed
+	default:
+		return StateUnknown
+	}
+}
+
+func (c *Client) write(b []byte) error {
+	_, err := c.conn.Write(b)
+	if err != nil {
+		return fmt.Errorf("dinitctl: write: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) read(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(c.conn, buf); err != nil {
+		return nil, fmt.Errorf("dinitctl: read: %w", err)
+	}
+	return buf, nil
+}
+
+func (c *Client) discard(n int) error {
+	_, err := c.read(n)
+	return err
+}
+
+func (c *Client) readReply() (byte, error) {
+	b, err := c.read(1)
+	if err != nil {
+		return 0, err
+	}
+	if b[0] == rpBadReq {
+		return 0, errors.New("dinitctl: dinit rejected request (protocol mismatch?)")
+	}
+	return b[0], nil
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	var buf [2]byte
+	binary.LittleEndian.PutUint16(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,174 @@
+package dinitctl
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+)
+
+// fakeService is a service the fake server below knows about.
+type fakeService struct {
+	handle uint32
+	state  State
+}
+
+// runFakeServer plays the server side of the control protocol over conn:
+// it answers the version handshake, then LOADSERVICE and SERVICESTATUS
+// requests against services, failing the test on any other opcode so a
+// client bug (e.g. sending the wrong opcode) is caught mechanically rather
+// than by inspection. It returns once conn is closed by the client.
+func runFakeServer(t *testing.T, conn net.Conn, services map[string]fakeService) {
+	t.Helper()
+	defer conn.Close()
+
+	if _, err := readN(conn, 1); err != nil { // cpQueryVersion
+		return
+	}
+	if _, err := conn.Write([]byte{rpCpVersion, 0, 0, 0, 0}); err != nil {
+		t.Errorf("fake server: write version reply: %v", err)
+		return
+	}
+
+	for {
+		op, err := readN(conn, 1)
+		if err != nil {
+			return // client closed the connection
+		}
+		switch op[0] {
+		case cpLoadService:
+			lenBytes, err := readN(conn, 2)
+			if err != nil {
+				t.Errorf("fake server: read name length: %v", err)
+				return
+			}
+			nameBytes, err := readN(conn, int(binary.LittleEndian.Uint16(lenBytes)))
+			if err != nil {
+				t.Errorf("fake server: read name: %v", err)
+				return
+			}
+			svc, ok := services[string(nameBytes)]
+			if !ok {
+				if _, err := conn.Write([]byte{rpNoService}); err != nil {
+					t.Errorf("fake server: write rpNoService: %v", err)
+					return
+				}
+				continue
+			}
+			body := make([]byte, 6)
+			body[0] = rpServiceRecord
+			binary.LittleEndian.PutUint32(body[2:], svc.handle)
+			if _, err := conn.Write(body); err != nil {
+				t.Errorf("fake server: write service record: %v", err)
+				return
+			}
+		case cpServiceStatus:
+			handleBytes, err := readN(conn, 4)
+			if err != nil {
+				t.Errorf("fake server: read handle: %v", err)
+				return
+			}
+			handle := binary.LittleEndian.Uint32(handleBytes)
+			var state State
+			for _, svc := range services {
+				if svc.handle == handle {
+					state = svc.state
+				}
+			}
+			if _, err := conn.Write([]byte{rpServiceStatus, encodeState(state)}); err != nil {
+				t.Errorf("fake server: write status reply: %v", err)
+				return
+			}
+		default:
+			t.Errorf("fake server: unexpected opcode %#x", op[0])
+			return
+		}
+	}
+}
+
+// encodeState is decodeState's inverse, for the fake server to produce wire
+// bytes a real dinit would send.
+func encodeState(s State) byte {
+	switch s {
+	case StateStopped:
+		return 0
+	case StateStarting:
+		return 1
+	case StateStarted:
+		return 2
+	case StateStopping:
+		return 3
+	case StateFailed:
+		return 4
+	default:
+		return 255
+	}
+}
+
+func readN(r io.Reader, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := io.ReadFull(r, buf)
+	return buf, err
+}
+
+// newTestClient connects a Client to a fake server backed by services.
+func newTestClient(t *testing.T, services map[string]fakeService) *Client {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	go runFakeServer(t, serverConn, services)
+
+	c := &Client{conn: clientConn}
+	if err := c.queryVersion(); err != nil {
+		t.Fatalf("queryVersion() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestServiceStatusKnown(t *testing.T) {
+	c := newTestClient(t, map[string]fakeService{
+		"nginx": {handle: 7, state: StateStarted},
+	})
+
+	status, err := c.ServiceStatus("nginx")
+	if err != nil {
+		t.Fatalf("ServiceStatus() error = %v", err)
+	}
+	if !status.Known {
+		t.Fatal("ServiceStatus().Known = false, want true")
+	}
+	if status.State != StateStarted {
+		t.Errorf("ServiceStatus().State = %v, want %v", status.State, StateStarted)
+	}
+}
+
+func TestServiceStatusUnknown(t *testing.T) {
+	c := newTestClient(t, map[string]fakeService{
+		"nginx": {handle: 7, state: StateStarted},
+	})
+
+	status, err := c.ServiceStatus("does-not-exist")
+	if err != nil {
+		t.Fatalf("ServiceStatus() error = %v", err)
+	}
+	if status.Known {
+		t.Errorf("ServiceStatus().Known = true, want false for an unloaded service")
+	}
+}
+
+func TestServiceStatusMultipleServices(t *testing.T) {
+	c := newTestClient(t, map[string]fakeService{
+		"nginx":    {handle: 1, state: StateStarted},
+		"postgres": {handle: 2, state: StateFailed},
+	})
+
+	for name, want := range map[string]State{"nginx": StateStarted, "postgres": StateFailed} {
+		status, err := c.ServiceStatus(name)
+		if err != nil {
+			t.Fatalf("ServiceStatus(%q) error = %v", name, err)
+		}
+		if status.State != want {
+			t.Errorf("ServiceStatus(%q).State = %v, want %v", name, status.State, want)
+		}
+	}
+}
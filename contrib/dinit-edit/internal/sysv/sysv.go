@@ -0,0 +1,168 @@
+// Package sysv discovers SysV init scripts (/etc/init.d and friends) and
+// the LSB metadata and runlevel symlinks describing how they start, as a
+// first step toward generating equivalent dinit service files.
+package sysv
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Script is a single SysV init script along with what we could determine
+// about how and when it's meant to start.
+type Script struct {
+	// Name is the script's file name under its init.d directory; it
+	// doubles as the dinit service name generated for it.
+	Name string
+	// Path is the absolute path to the script.
+	Path string
+	// Provides lists the LSB facility names this script provides. Name is
+	// always included even if the script has no LSB header.
+	Provides []string
+	// RequiredStart lists the LSB facility names (from Required-Start)
+	// this script must start after.
+	RequiredStart []string
+	// DefaultStart lists the runlevels this script starts in, either from
+	// the LSB Default-Start header or, failing that, from S-prefixed
+	// symlinks found under the runlevel directories.
+	DefaultStart []int
+}
+
+// lsbHeaderStart and lsbHeaderEnd delimit the LSB header block within an
+// init script's leading comments.
+const (
+	lsbHeaderStart = "### BEGIN INIT INFO"
+	lsbHeaderEnd   = "### END INIT INFO"
+)
+
+// lsbField matches a "# Key: value" header line.
+var lsbField = regexp.MustCompile(`^#\s*([\w-]+):\s*(.*)$`)
+
+// ParseLSBHeader reads an init script and extracts its LSB header fields.
+// A script with no header (or no recognised fields) returns an empty map,
+// since SysV scripts aren't required to have one.
+func ParseLSBHeader(r io.Reader) (fields map[string][]string, err error) {
+	fields = map[string][]string{}
+	scanner := bufio.NewScanner(r)
+	inHeader := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		switch {
+		case strings.HasPrefix(line, lsbHeaderStart):
+			inHeader = true
+		case strings.HasPrefix(line, lsbHeaderEnd):
+			return fields, scanner.Err()
+		case inHeader:
+			if m := lsbField.FindStringSubmatch(line); m != nil {
+				key, value := m[1], strings.TrimSpace(m[2])
+				fields[key] = splitTokens(value)
+			}
+		}
+	}
+	return fields, scanner.Err()
+}
+
+func splitTokens(value string) []string {
+	return strings.Fields(value)
+}
+
+// Discover scans initdDir for SysV init scripts (regular, executable
+// files) and parses each one's LSB header.
+func Discover(initdDir string) ([]Script, error) {
+	entries, err := os.ReadDir(initdDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var scripts []Script
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+			continue
+		}
+		name := entry.Name()
+		path := filepath.Join(initdDir, name)
+		script := Script{Name: name, Path: path, Provides: []string{name}}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := ParseLSBHeader(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		if provides := fields["Provides"]; len(provides) > 0 {
+			script.Provides = dedupStrings(append(script.Provides, provides...))
+		}
+		script.RequiredStart = fields["Required-Start"]
+		for _, level := range fields["Default-Start"] {
+			if n, err := strconv.Atoi(level); err == nil {
+				script.DefaultStart = append(script.DefaultStart, n)
+			}
+		}
+		scripts = append(scripts, script)
+	}
+
+	sort.Slice(scripts, func(i, j int) bool { return scripts[i].Name < scripts[j].Name })
+	return scripts, nil
+}
+
+// runlevelSymlink matches a runlevel start symlink, e.g. "S20apache2".
+var runlevelSymlink = regexp.MustCompile(`^S\d+(.+)$`)
+
+// FillRunlevelsFromSymlinks scans rcDirs (runlevel number -> directory
+// path, e.g. {2: "/etc/rc2.d"}) for S-prefixed start symlinks and fills in
+// DefaultStart on any script in scripts whose LSB header didn't already
+// supply one.
+func FillRunlevelsFromSymlinks(scripts []Script, rcDirs map[int]string) {
+	byName := make(map[string]int, len(scripts))
+	hasHeaderLevels := make([]bool, len(scripts))
+	for i, s := range scripts {
+		byName[s.Name] = i
+		hasHeaderLevels[i] = len(s.DefaultStart) > 0
+	}
+
+	levels := make([]int, 0, len(rcDirs))
+	for level := range rcDirs {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+
+	for _, level := range levels {
+		entries, err := os.ReadDir(rcDirs[level])
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			m := runlevelSymlink.FindStringSubmatch(entry.Name())
+			if m == nil {
+				continue
+			}
+			i, ok := byName[m[1]]
+			if !ok || hasHeaderLevels[i] {
+				continue
+			}
+			scripts[i].DefaultStart = append(scripts[i].DefaultStart, level)
+		}
+	}
+}
+
+func dedupStrings(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
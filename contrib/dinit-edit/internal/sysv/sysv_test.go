@@ -0,0 +1,93 @@
+package sysv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const sampleScript = `#!/bin/sh
+### BEGIN INIT INFO
+# Provides:          apache2
+# Required-Start:    $local_fs $remote_fs $network
+# Required-Stop:     $local_fs $remote_fs $network
+# Default-Start:     2 3 4 5
+# Default-Stop:      0 1 6
+# Short-Description: Apache web server
+### END INIT INFO
+
+echo hello
+`
+
+func TestParseLSBHeader(t *testing.T) {
+	fields, err := ParseLSBHeader(strings.NewReader(sampleScript))
+	if err != nil {
+		t.Fatalf("ParseLSBHeader() error = %v", err)
+	}
+	want := map[string][]string{
+		"Provides":          {"apache2"},
+		"Required-Start":    {"$local_fs", "$remote_fs", "$network"},
+		"Required-Stop":     {"$local_fs", "$remote_fs", "$network"},
+		"Default-Start":     {"2", "3", "4", "5"},
+		"Default-Stop":      {"0", "1", "6"},
+		"Short-Description": {"Apache", "web", "server"},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("ParseLSBHeader() = %v, want %v", fields, want)
+	}
+}
+
+func TestParseLSBHeaderNoHeader(t *testing.T) {
+	fields, err := ParseLSBHeader(strings.NewReader("#!/bin/sh\necho hi\n"))
+	if err != nil {
+		t.Fatalf("ParseLSBHeader() error = %v", err)
+	}
+	if len(fields) != 0 {
+		t.Errorf("ParseLSBHeader() = %v, want empty", fields)
+	}
+}
+
+func TestFillRunlevelsFromSymlinksCollectsAllLevels(t *testing.T) {
+	root := t.TempDir()
+	rcDirs := map[int]string{}
+	for _, level := range []int{2, 3, 4, 5} {
+		dir := filepath.Join(root, "rc"+strconv.Itoa(level)+".d")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "S20apache2"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		rcDirs[level] = dir
+	}
+
+	scripts := []Script{{Name: "apache2"}}
+	FillRunlevelsFromSymlinks(scripts, rcDirs)
+
+	want := []int{2, 3, 4, 5}
+	if !reflect.DeepEqual(scripts[0].DefaultStart, want) {
+		t.Errorf("DefaultStart = %v, want %v", scripts[0].DefaultStart, want)
+	}
+}
+
+func TestFillRunlevelsFromSymlinksDoesNotOverrideLSBHeader(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "rc2.d")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "S20apache2"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scripts := []Script{{Name: "apache2", DefaultStart: []int{3, 4, 5}}}
+	FillRunlevelsFromSymlinks(scripts, map[int]string{2: dir})
+
+	want := []int{3, 4, 5}
+	if !reflect.DeepEqual(scripts[0].DefaultStart, want) {
+		t.Errorf("DefaultStart = %v, want %v (LSB header should win)", scripts[0].DefaultStart, want)
+	}
+}
@@ -0,0 +1,86 @@
+// Package xdg implements the parts of the XDG Base Directory Specification
+// that dinit-edit (and future dinit tooling) needs: locating the user's
+// config home, the system-wide config search path, and combining the two
+// into an ordered, deduplicated list of candidate directories.
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultConfigDirs is used when $XDG_CONFIG_DIRS is unset or empty, per spec.
+const defaultConfigDirs = "/etc/xdg"
+
+// lookupEnv and userHomeDir are overridden in tests so the resolver can be
+// exercised against a mock environment instead of the real process one.
+var (
+	lookupEnv   = os.LookupEnv
+	userHomeDir = os.UserHomeDir
+)
+
+// ConfigHome returns the user's XDG config home: $XDG_CONFIG_HOME if set to a
+// non-empty, absolute path, otherwise $HOME/.config (via os.UserHomeDir, so
+// this also works when HOME isn't exported but the OS can still resolve the
+// home directory, e.g. from /etc/passwd).
+func ConfigHome() (string, error) {
+	if dir, ok := lookupEnv("XDG_CONFIG_HOME"); ok && filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config"), nil
+}
+
+// ConfigDirs returns the ordered list of system-wide configuration search
+// directories from $XDG_CONFIG_DIRS (colon-separated), falling back to
+// /etc/xdg when the variable is unset, empty, or contains no absolute paths.
+func ConfigDirs() []string {
+	raw, ok := lookupEnv("XDG_CONFIG_DIRS")
+	if !ok || raw == "" {
+		raw = defaultConfigDirs
+	}
+	var dirs []string
+	for _, dir := range strings.Split(raw, ":") {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+	if len(dirs) == 0 {
+		dirs = []string{defaultConfigDirs}
+	}
+	return dirs
+}
+
+// SearchDirs returns the ordered, deduplicated list of candidate directories
+// for suffix (e.g. "dinit.d"): the user's config home first, then each
+// system config directory, in $XDG_CONFIG_DIRS order. Directories that can't
+// be resolved (e.g. ConfigHome failing because HOME can't be determined) are
+// silently skipped rather than aborting the whole search.
+func SearchDirs(suffix string) []string {
+	var candidates []string
+	if home, err := ConfigHome(); err == nil {
+		candidates = append(candidates, filepath.Join(home, suffix))
+	}
+	for _, dir := range ConfigDirs() {
+		candidates = append(candidates, filepath.Join(dir, suffix))
+	}
+	return dedup(candidates)
+}
+
+// dedup removes duplicate and empty entries while preserving order.
+func dedup(paths []string) []string {
+	seen := make(map[string]bool, len(paths))
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	return out
+}
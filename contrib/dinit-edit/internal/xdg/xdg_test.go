@@ -0,0 +1,98 @@
+package xdg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var errHome = errors.New("cannot determine home directory")
+
+// withEnv installs a mock environment for the duration of a test and
+// restores the real lookupEnv/userHomeDir funcs on cleanup.
+func withEnv(t *testing.T, env map[string]string, home string, homeErr error) {
+	t.Helper()
+	origLookup, origHome := lookupEnv, userHomeDir
+	lookupEnv = func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	userHomeDir = func() (string, error) {
+		return home, homeErr
+	}
+	t.Cleanup(func() {
+		lookupEnv, userHomeDir = origLookup, origHome
+	})
+}
+
+func TestConfigHomeFromEnv(t *testing.T) {
+	withEnv(t, map[string]string{"XDG_CONFIG_HOME": "/home/alice/.config"}, "/home/alice", nil)
+	got, err := ConfigHome()
+	if err != nil {
+		t.Fatalf("ConfigHome() error = %v", err)
+	}
+	if got != "/home/alice/.config" {
+		t.Errorf("ConfigHome() = %q, want %q", got, "/home/alice/.config")
+	}
+}
+
+func TestConfigHomeFallsBackToUserHomeDir(t *testing.T) {
+	withEnv(t, map[string]string{}, "/home/bob", nil)
+	got, err := ConfigHome()
+	if err != nil {
+		t.Fatalf("ConfigHome() error = %v", err)
+	}
+	if want := "/home/bob/.config"; got != want {
+		t.Errorf("ConfigHome() = %q, want %q", got, want)
+	}
+}
+
+func TestConfigHomeIgnoresRelativeXDGConfigHome(t *testing.T) {
+	withEnv(t, map[string]string{"XDG_CONFIG_HOME": "relative/path"}, "/home/carol", nil)
+	got, err := ConfigHome()
+	if err != nil {
+		t.Fatalf("ConfigHome() error = %v", err)
+	}
+	if want := "/home/carol/.config"; got != want {
+		t.Errorf("ConfigHome() = %q, want %q", got, want)
+	}
+}
+
+func TestConfigDirsDefault(t *testing.T) {
+	withEnv(t, map[string]string{}, "/home/dave", nil)
+	got := ConfigDirs()
+	want := []string{"/etc/xdg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigDirs() = %v, want %v", got, want)
+	}
+}
+
+func TestConfigDirsFromEnv(t *testing.T) {
+	withEnv(t, map[string]string{"XDG_CONFIG_DIRS": "/etc/xdg/site:/opt/xdg"}, "/home/erin", nil)
+	got := ConfigDirs()
+	want := []string{"/etc/xdg/site", "/opt/xdg"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigDirs() = %v, want %v", got, want)
+	}
+}
+
+func TestSearchDirsDedupesAndOrders(t *testing.T) {
+	withEnv(t, map[string]string{
+		"XDG_CONFIG_HOME": "/home/frank/.config",
+		"XDG_CONFIG_DIRS": "/etc/xdg:/etc/xdg",
+	}, "/home/frank", nil)
+	got := SearchDirs("dinit.d")
+	want := []string{"/home/frank/.config/dinit.d", "/etc/xdg/dinit.d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchDirs() = %v, want %v", got, want)
+	}
+}
+
+func TestSearchDirsSkipsUnresolvableConfigHome(t *testing.T) {
+	withEnv(t, map[string]string{"XDG_CONFIG_DIRS": "/etc/xdg"}, "", errHome)
+	got := SearchDirs("dinit.d")
+	want := []string{"/etc/xdg/dinit.d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchDirs() = %v, want %v", got, want)
+	}
+}
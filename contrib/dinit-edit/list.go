@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/davmac314/dinit/contrib/dinit-edit/internal/dinitctl"
+)
+
+// serviceView is a Service annotated with its live dinit state, if known.
+// Known is false when dinit is reachable but has never loaded the service;
+// State is the zero value when dinit isn't reachable at all.
+type serviceView struct {
+	Name      string   `json:"name"`
+	Path      string   `json:"path"`
+	Overrides []string `json:"overrides,omitempty"`
+	Known     bool     `json:"known,omitempty"`
+	State     string   `json:"state,omitempty"`
+}
+
+func listAll(services []Service) {
+	wantStatus := *statusFlag || *runningOnlyFlag || *failedOnlyFlag
+
+	var statuses map[string]dinitctl.Status
+	if wantStatus {
+		var err error
+		statuses, err = fetchStatuses(services)
+		if err != nil {
+			if *runningOnlyFlag || *failedOnlyFlag {
+				fmt.Fprintln(os.Stderr, "dinit-edit:", err)
+				os.Exit(1)
+			}
+			// -status alone degrades gracefully: list without annotations.
+			fmt.Fprintln(os.Stderr, "dinit-edit: warning:", err)
+			wantStatus = false
+		}
+	}
+
+	views := make([]serviceView, 0, len(services))
+	for _, srv := range services {
+		view := serviceView{Name: srv.Name, Path: srv.BasePath, Overrides: srv.Overrides}
+		if st, ok := statuses[srv.Name]; ok {
+			view.Known = st.Known
+			if st.Known {
+				view.State = st.State.String()
+			}
+		}
+		if *runningOnlyFlag && !isRunning(view) {
+			continue
+		}
+		if *failedOnlyFlag && view.State != dinitctl.StateFailed.String() {
+			continue
+		}
+		views = append(views, view)
+	}
+
+	if *jsonFlag {
+		printJSON(views)
+	} else {
+		printTable(views, wantStatus)
+	}
+}
+
+func isRunning(v serviceView) bool {
+	return v.State == dinitctl.StateStarting.String() || v.State == dinitctl.StateStarted.String()
+}
+
+// fetchStatuses queries dinit's control socket for every service's status.
+// It returns a partial or empty map alongside an error if the socket can't
+// be reached - callers decide whether that's fatal.
+func fetchStatuses(services []Service) (map[string]dinitctl.Status, error) {
+	client, err := dinitctl.Dial(dinitctl.Discover())
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	statuses := make(map[string]dinitctl.Status, len(services))
+	for _, srv := range services {
+		st, err := client.ServiceStatus(srv.Name)
+		if err != nil {
+			return statuses, err
+		}
+		statuses[srv.Name] = st
+	}
+	return statuses, nil
+}
+
+func printTable(views []serviceView, withStatus bool) {
+	longest := 0
+	for _, v := range views {
+		longest = max(longest, len(v.Name))
+	}
+	for _, v := range views {
+		if withStatus {
+			state := "?"
+			if v.Known {
+				state = v.State
+			} else {
+				state = "unknown to dinit"
+			}
+			fmt.Fprintf(os.Stdout, "%-*s%-18s%s\n", longest+4, v.Name, state, v.Path)
+		} else {
+			fmt.Fprintf(os.Stdout, "%-*s%s\n", longest+4, v.Name, v.Path)
+		}
+		for _, override := range v.Overrides {
+			fmt.Fprintf(os.Stdout, "%-*s  + %s\n", longest+4, "", override)
+		}
+	}
+}
+
+func printJSON(views []serviceView) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(views); err != nil {
+		fmt.Fprintln(os.Stderr, "dinit-edit:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Service is a dinit service found in one of the search directories, along
+// with any drop-in override files found for it (systemd-style "foo.d/*.conf"
+// directories, checked across every search directory, not just the one
+// BasePath came from).
+type Service struct {
+	Name      string
+	BasePath  string
+	Overrides []string
+}
+
+// readDir is os.ReadDir, indirected so tests and benchmarks can substitute
+// a slower or error-injecting implementation.
+var readDir = os.ReadDir
+
+// dirScan is what scanDir finds in a single search directory: base service
+// files, and any "<name>.d/*.conf" override files nested in it.
+type dirScan struct {
+	priority        int
+	bases           []Service
+	overridesByName map[string][]string
+	err             error
+}
+
+// scanServices scans dirs for services and their overrides, with no
+// deadline on the scan.
+func scanServices(dirs []string) []Service {
+	return scanServicesContext(context.Background(), dirs)
+}
+
+// scanWithTimeout scans dirs with the given deadline applied to the whole
+// scan, or with no deadline at all when timeout is zero.
+func scanWithTimeout(dirs []string, timeout time.Duration) []Service {
+	if timeout <= 0 {
+		return scanServices(dirs)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return scanServicesContext(ctx, dirs)
+}
+
+// scanServicesContext scans dirs concurrently, one goroutine per directory,
+// and merges the results once every directory has reported in or ctx is
+// done - whichever comes first. A directory that's still pending when ctx
+// fires (typically a hung automount) is simply left out, so callers on a
+// deadline get a partial list rather than hanging indefinitely.
+//
+// Directories are tagged with their position in dirs (their search
+// priority) so that, despite scanning concurrently, results are merged in
+// the same priority order as the old sequential loop: the first directory
+// to provide a given service name wins its BasePath, and overrides are
+// listed in directory-priority order too.
+func scanServicesContext(ctx context.Context, dirs []string) []Service {
+	results := make([]*dirScan, len(dirs))
+	scans := make(chan dirScan, len(dirs))
+	for i, dir := range dirs {
+		go func(priority int, dir string) {
+			r := scanDir(priority, dir)
+			select {
+			case scans <- r:
+			case <-ctx.Done():
+			}
+		}(i, dir)
+	}
+
+	for range dirs {
+		select {
+		case r := <-scans:
+			results[r.priority] = &r
+		case <-ctx.Done():
+			return mergeScans(results)
+		}
+	}
+	return mergeScans(results)
+}
+
+// scanDir reads a single search directory, collecting its regular files as
+// candidate base services and its "<name>.d" subdirectories as override
+// sources for whatever service ends up matching <name>.
+func scanDir(priority int, dir string) dirScan {
+	result := dirScan{priority: priority, overridesByName: map[string][]string{}}
+
+	entries, err := readDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			result.err = err
+		}
+		return result
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case entry.Type().IsRegular():
+			result.bases = append(result.bases, Service{Name: name, BasePath: path.Join(dir, name)})
+		case entry.IsDir() && strings.HasSuffix(name, ".d"):
+			svcName := strings.TrimSuffix(name, ".d")
+			overrideDir := path.Join(dir, name)
+			confEntries, err := readDir(overrideDir)
+			if err != nil {
+				continue
+			}
+			for _, c := range confEntries {
+				if c.Type().IsRegular() && strings.HasSuffix(c.Name(), ".conf") {
+					result.overridesByName[svcName] = append(result.overridesByName[svcName], path.Join(overrideDir, c.Name()))
+				}
+			}
+		}
+	}
+	return result
+}
+
+// mergeScans combines per-directory scan results, in priority order, into
+// the final service list. Entries for directories that never reported in
+// (nil in results) are simply skipped.
+func mergeScans(results []*dirScan) []Service {
+	services := []Service{}
+	index := map[string]int{}
+
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		if r.err != nil {
+			fmt.Println(r.err)
+		}
+		for _, base := range r.bases {
+			if _, exists := index[base.Name]; exists {
+				continue
+			}
+			index[base.Name] = len(services)
+			services = append(services, base)
+		}
+	}
+
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		for name, overrides := range r.overridesByName {
+			if i, ok := index[name]; ok {
+				services[i].Overrides = append(services[i].Overrides, overrides...)
+			}
+		}
+	}
+
+	return services
+}
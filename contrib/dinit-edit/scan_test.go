@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeFixture builds n search directories under t.TempDir(), each with a
+// base service file "svcN" and an override "svcN.d/local.conf", and returns
+// their paths in priority order.
+func writeFixture(t *testing.T, n int) []string {
+	t.Helper()
+	root := t.TempDir()
+	dirs := make([]string, n)
+	for i := 0; i < n; i++ {
+		dir := filepath.Join(root, "dir"+strconv.Itoa(i))
+		name := "svc" + strconv.Itoa(i)
+		if err := os.MkdirAll(filepath.Join(dir, name+".d"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("type = process\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".d", "local.conf"), []byte("restart = true\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		dirs[i] = dir
+	}
+	return dirs
+}
+
+func TestScanServicesCollectsBasesAndOverrides(t *testing.T) {
+	dirs := writeFixture(t, 3)
+	services := scanServices(dirs)
+
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	if len(services) != 3 {
+		t.Fatalf("scanServices() returned %d services, want 3", len(services))
+	}
+	for i, srv := range services {
+		wantName := "svc" + strconv.Itoa(i)
+		if srv.Name != wantName {
+			t.Errorf("services[%d].Name = %q, want %q", i, srv.Name, wantName)
+		}
+		if len(srv.Overrides) != 1 {
+			t.Errorf("services[%d].Overrides = %v, want 1 entry", i, srv.Overrides)
+		}
+	}
+}
+
+func TestScanServicesContextPartialOnDeadline(t *testing.T) {
+	dirs := writeFixture(t, 4)
+
+	origReadDir := readDir
+	var calls int32
+	readDir = func(dir string) ([]os.DirEntry, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			time.Sleep(50 * time.Millisecond)
+		}
+		return origReadDir(dir)
+	}
+	t.Cleanup(func() { readDir = origReadDir })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	services := scanServicesContext(ctx, dirs)
+	if len(services) >= 4 {
+		t.Errorf("scanServicesContext() with a short deadline returned all %d services, want a partial list", len(services))
+	}
+}
+
+func TestMergeScansFirstDirWins(t *testing.T) {
+	results := []*dirScan{
+		{priority: 0, bases: []Service{{Name: "svc", BasePath: "/a/svc"}}, overridesByName: map[string][]string{}},
+		{priority: 1, bases: []Service{{Name: "svc", BasePath: "/b/svc"}}, overridesByName: map[string][]string{"svc": {"/b/svc.d/local.conf"}}},
+	}
+	services := mergeScans(results)
+	want := []Service{{Name: "svc", BasePath: "/a/svc", Overrides: []string{"/b/svc.d/local.conf"}}}
+	if !reflect.DeepEqual(services, want) {
+		t.Errorf("mergeScans() = %+v, want %+v", services, want)
+	}
+}
+
+// BenchmarkScanServicesSequential simulates the old one-goroutine-total loop
+// by scanning directories one at a time, to compare against the concurrent
+// scanner below on a fixture where every directory read is artificially
+// slow (standing in for a slow/automounted network filesystem).
+func BenchmarkScanServicesSequential(b *testing.B) {
+	dirs := benchFixture(b, 8)
+	withSimulatedLatency(b, 5*time.Millisecond, func() {
+		for i := 0; i < b.N; i++ {
+			results := make([]*dirScan, len(dirs))
+			for j, dir := range dirs {
+				r := scanDir(j, dir)
+				results[j] = &r
+			}
+			mergeScans(results)
+		}
+	})
+}
+
+func BenchmarkScanServicesConcurrent(b *testing.B) {
+	dirs := benchFixture(b, 8)
+	withSimulatedLatency(b, 5*time.Millisecond, func() {
+		for i := 0; i < b.N; i++ {
+			scanServices(dirs)
+		}
+	})
+}
+
+func benchFixture(b *testing.B, n int) []string {
+	b.Helper()
+	root := b.TempDir()
+	dirs := make([]string, n)
+	for i := 0; i < n; i++ {
+		dir := filepath.Join(root, "dir"+strconv.Itoa(i))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "svc"+strconv.Itoa(i)), nil, 0644); err != nil {
+			b.Fatal(err)
+		}
+		dirs[i] = dir
+	}
+	return dirs
+}
+
+// withSimulatedLatency runs fn with readDir replaced by a version that
+// sleeps for delay before every call, so the benchmark reflects directory
+// I/O latency rather than tmpfs speed.
+func withSimulatedLatency(b *testing.B, delay time.Duration, fn func()) {
+	b.Helper()
+	orig := readDir
+	readDir = func(dir string) ([]os.DirEntry, error) {
+		time.Sleep(delay)
+		return orig(dir)
+	}
+	defer func() { readDir = orig }()
+	fn()
+}